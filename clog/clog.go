@@ -0,0 +1,26 @@
+// Package clog provides a thin leveled-logging facade used throughout
+// resticprofile so the rest of the codebase doesn't depend directly on
+// a particular logging library.
+package clog
+
+import "log"
+
+// Infof logs an informational message
+func Infof(format string, args ...interface{}) {
+	log.Printf("INFO: "+format, args...)
+}
+
+// Debugf logs a debug message
+func Debugf(format string, args ...interface{}) {
+	log.Printf("DEBUG: "+format, args...)
+}
+
+// Warningf logs a warning message
+func Warningf(format string, args ...interface{}) {
+	log.Printf("WARNING: "+format, args...)
+}
+
+// Errorf logs an error message
+func Errorf(format string, args ...interface{}) {
+	log.Printf("ERROR: "+format, args...)
+}
@@ -0,0 +1,76 @@
+// Package config parses resticprofile's configuration (TOML, YAML, JSON,
+// HCL or dotenv, see LoadConfiguration) into Profile objects: named sections describing how to run restic for a particular
+// repository, plus a top level [global] section and [groups] of profiles.
+package config
+
+import (
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/spf13/viper"
+)
+
+// ProfileKeys returns the names of the profiles declared in the
+// configuration (the top level sections, excluding [global] and [groups])
+// that set at least one key; see profileNames.
+func ProfileKeys() []string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return profileNames()
+}
+
+// ProfileSections returns the names of the profiles that actually carry
+// some configuration; it is the same list as ProfileKeys. A profile with
+// only its header is not listed, although LoadProfile can still load it.
+func ProfileSections() []string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return profileNames()
+}
+
+// profileNames walks every key known to viper and collects the distinct
+// top level section names, skipping the reserved [global] and [groups]
+// sections. A profile declared with no content at all (just its header)
+// never sets any key, so it won't show up here.
+func profileNames() []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+
+	for _, key := range viper.AllKeys() {
+		name := strings.SplitN(key, ".", 2)[0]
+		if name == constants.SectionConfigurationGlobal || name == constants.SectionConfigurationGroups {
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
+
+// ProfileGroups returns the [groups] section of the configuration, mapping
+// a group name to the list of profile names it contains. It returns nil
+// when no [groups] section is present at all. Both the `name = [...]`
+// shorthand and the `[groups.name]` table form are understood, same as
+// LoadGroup.
+func ProfileGroups() map[string][]string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	if !viper.IsSet(constants.SectionConfigurationGroups) {
+		return nil
+	}
+
+	groups := make(map[string][]string)
+	for name := range viper.GetStringMap(constants.SectionConfigurationGroups) {
+		if group, err := resolveGroup(name); err == nil {
+			groups[name] = group.Profiles
+		}
+	}
+	return groups
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/clog"
+)
+
+// envPrefix is the prefix set through SetEnvPrefix used to derive automatic
+// environment variable names for a profile's flags.
+var envPrefix string
+
+// SetEnvPrefix enables automatic environment variable overrides for every
+// profile: a flag named key on a profile named profileName can be
+// overridden by <PREFIX>_<PROFILE>_<KEY>, e.g. RESTICPROFILE_<PROFILE>_<KEY>
+// for prefix "RESTICPROFILE" (the name is upper-cased, with '-' and '.'
+// turned into '_').
+func SetEnvPrefix(prefix string) {
+	envPrefix = prefix
+}
+
+// BindEnv registers one or more environment variable names that can
+// override key on this profile. Names are checked in order and the first
+// one that is set (even to an empty value) wins over the config file
+// value, so a user can explicitly clear an inherited flag by setting its
+// override env var to an empty string.
+func (p *Profile) BindEnv(key string, envNames ...string) {
+	if p.envBindings == nil {
+		p.envBindings = make(map[string][]string)
+	}
+	p.envBindings[key] = envNames
+}
+
+// lookupEnv resolves key through the env vars bound via BindEnv, falling
+// back to the automatic <PREFIX>_<PROFILE>_<KEY> name when a prefix
+// was set with SetEnvPrefix.
+func (p *Profile) lookupEnv(key string) (string, bool) {
+	for _, name := range p.envBindings[key] {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+	}
+	if envPrefix != "" {
+		if value, ok := os.LookupEnv(automaticEnvName(envPrefix, p.Name, key)); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func automaticEnvName(prefix, profileName, key string) string {
+	name := strings.Join([]string{prefix, profileName, key}, "_")
+	name = strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	return strings.ToUpper(name)
+}
+
+// fixedEnvKeys are the Profile fields that have their own typed storage
+// (as opposed to living in OtherFlags), so they're always checked for an
+// override regardless of whether BindEnv was called for them.
+var fixedEnvKeys = []string{"quiet", "verbose", "initialize", "repository", "host"}
+
+// resolveEnvOverrides applies any bound environment variable to the
+// profile's flags, in place, before they're turned into command line
+// arguments. This covers the fixedEnvKeys fields, any key explicitly
+// registered with BindEnv, and any key already present in OtherFlags -
+// so a caller can override an arbitrary flag (e.g. "compression"), not
+// just the handful of fields Profile special-cases.
+func (p *Profile) resolveEnvOverrides() {
+	keys := make(map[string]bool, len(fixedEnvKeys)+len(p.envBindings)+len(p.OtherFlags))
+	for _, key := range fixedEnvKeys {
+		keys[key] = true
+	}
+	for key := range p.envBindings {
+		keys[key] = true
+	}
+	for key := range p.OtherFlags {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		if value, ok := p.lookupEnv(key); ok {
+			p.applyEnvOverride(key, value)
+		}
+	}
+}
+
+// applyEnvOverride stores value (resolved from an environment variable)
+// as the override for key. The fixedEnvKeys fields go to their own typed
+// field; everything else goes into OtherFlags, same as a value loaded
+// from the config file, with an empty value clearing a previously set
+// flag entirely.
+func (p *Profile) applyEnvOverride(key, value string) {
+	switch key {
+	case "quiet":
+		p.Quiet = parseBoolOverride(key, value, p.Quiet)
+	case "verbose":
+		p.Verbose = parseBoolOverride(key, value, p.Verbose)
+	case "initialize":
+		p.Initialize = parseBoolOverride(key, value, p.Initialize)
+	case "repository":
+		p.Repository = value
+	case "host":
+		p.host = value
+	default:
+		if value == "" {
+			delete(p.OtherFlags, key)
+			return
+		}
+		if p.OtherFlags == nil {
+			p.OtherFlags = make(map[string]interface{})
+		}
+		p.OtherFlags[key] = value
+	}
+}
+
+func parseBoolOverride(key, value string, fallback bool) bool {
+	if value == "" {
+		return false
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		clog.Warningf("invalid boolean value for environment override of '%s': %v", key, err)
+		return fallback
+	}
+	return parsed
+}
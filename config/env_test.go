@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverriddenInitializeValueFalseFromEnv(t *testing.T) {
+	testConfig := `[profile]
+initialize = true
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	os.Setenv("TEST_PROFILE_INITIALIZE", "false")
+	defer os.Unsetenv("TEST_PROFILE_INITIALIZE")
+	profile.BindEnv("initialize", "TEST_PROFILE_INITIALIZE")
+
+	profile.GetCommonFlags()
+	assert.Equal(t, false, profile.Initialize)
+}
+
+func TestOverriddenRepositoryFromEnv(t *testing.T) {
+	testConfig := `[profile]
+repository = "file:///from-config"
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	os.Setenv("TEST_PROFILE_REPOSITORY", "s3:from-env")
+	defer os.Unsetenv("TEST_PROFILE_REPOSITORY")
+	profile.BindEnv("repository", "TEST_PROFILE_REPOSITORY")
+
+	flags := profile.GetCommonFlags()
+	assert.Contains(t, flags, "repo")
+	assert.Equal(t, []string{"s3:from-env"}, flags["repo"])
+}
+
+func TestOverriddenRepositoryEmptyEnvClearsValue(t *testing.T) {
+	testConfig := `[profile]
+repository = "file:///from-config"
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	os.Setenv("TEST_PROFILE_REPOSITORY", "")
+	defer os.Unsetenv("TEST_PROFILE_REPOSITORY")
+	profile.BindEnv("repository", "TEST_PROFILE_REPOSITORY")
+
+	flags := profile.GetCommonFlags()
+	assert.NotContains(t, flags, "repo")
+}
+
+func TestOverriddenHostFromEnv(t *testing.T) {
+	testConfig := `[profile]
+[profile.backup]
+host = true
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	profile.SetHost("ConfigHost")
+	os.Setenv("TEST_PROFILE_HOST", "EnvHost")
+	defer os.Unsetenv("TEST_PROFILE_HOST")
+	profile.BindEnv("host", "TEST_PROFILE_HOST")
+
+	flags := profile.GetCommandFlags("backup")
+	assert.Contains(t, flags, "host")
+	assert.Equal(t, []string{"EnvHost"}, flags["host"])
+}
+
+func TestMultiNameEnvPrecedence(t *testing.T) {
+	testConfig := `[profile]
+repository = "file:///from-config"
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	os.Setenv("TEST_PROFILE_REPOSITORY_2", "s3:second")
+	defer os.Unsetenv("TEST_PROFILE_REPOSITORY_2")
+	// TEST_PROFILE_REPOSITORY_1 is intentionally left unset: the first
+	// *set* name in the list should win, not necessarily the first one.
+	profile.BindEnv("repository", "TEST_PROFILE_REPOSITORY_1", "TEST_PROFILE_REPOSITORY_2")
+
+	flags := profile.GetCommonFlags()
+	assert.Equal(t, []string{"s3:second"}, flags["repo"])
+}
+
+func TestOverriddenOtherFlagFromEnv(t *testing.T) {
+	testConfig := `[profile]
+compression = "auto"
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	os.Setenv("TEST_PROFILE_COMPRESSION", "max")
+	defer os.Unsetenv("TEST_PROFILE_COMPRESSION")
+	profile.BindEnv("compression", "TEST_PROFILE_COMPRESSION")
+
+	flags := profile.GetCommonFlags()
+	assert.Equal(t, []string{"max"}, flags["compression"])
+}
+
+func TestOverriddenOtherFlagEmptyEnvClearsValue(t *testing.T) {
+	testConfig := `[profile]
+compression = "auto"
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	os.Setenv("TEST_PROFILE_COMPRESSION", "")
+	defer os.Unsetenv("TEST_PROFILE_COMPRESSION")
+	profile.BindEnv("compression", "TEST_PROFILE_COMPRESSION")
+
+	flags := profile.GetCommonFlags()
+	assert.NotContains(t, flags, "compression")
+}
+
+func TestAutomaticEnvPrefix(t *testing.T) {
+	testConfig := `[profile]
+repository = "file:///from-config"
+`
+	profile, err := getProfile(testConfig, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+
+	SetEnvPrefix("RESTICPROFILE")
+	defer SetEnvPrefix("")
+	os.Setenv("RESTICPROFILE_PROFILE_REPOSITORY", "s3:automatic")
+	defer os.Unsetenv("RESTICPROFILE_PROFILE_REPOSITORY")
+
+	flags := profile.GetCommonFlags()
+	assert.Equal(t, []string{"s3:automatic"}, flags["repo"])
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/spf13/viper"
+)
+
+// ErrGroupAborted is the error recorded for every profile in a group that
+// never ran because an earlier profile failed and the group isn't
+// configured to continue on error.
+var ErrGroupAborted = errors.New("aborted: a previous profile in the group failed")
+
+// Group is an executable [groups] entry: the list of profiles to run one
+// restic command against, plus how to handle failures and concurrency.
+type Group struct {
+	Name            string
+	Profiles        []string
+	ContinueOnError bool
+	Parallel        int
+}
+
+// LoadGroup reads the group named name from the configuration. It accepts
+// both the plain `name = ["profile1", "profile2"]` shorthand and the
+// table form:
+//
+//	[groups.name]
+//	profiles = ["profile1", "profile2"]
+//	continue-on-error = true
+//	parallel = 2
+func LoadGroup(name string) (*Group, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return resolveGroup(name)
+}
+
+// resolveGroup is the shared implementation behind LoadGroup and
+// ProfileGroups, so both agree on what a group's profile list is
+// regardless of which schema form it's declared in.
+func resolveGroup(name string) (*Group, error) {
+	key := constants.SectionConfigurationGroups + "." + name
+	if !viper.IsSet(key) {
+		return nil, fmt.Errorf("unknown group '%s'", name)
+	}
+
+	group := &Group{Name: name, Parallel: 1}
+
+	switch raw := viper.Get(key).(type) {
+	case []interface{}:
+		for _, entry := range raw {
+			if profileKey, ok := entry.(string); ok {
+				group.Profiles = append(group.Profiles, profileKey)
+			}
+		}
+	default:
+		group.Profiles = viper.GetStringSlice(key + ".profiles")
+		group.ContinueOnError = viper.GetBool(key + ".continue-on-error")
+		if viper.IsSet(key + ".parallel") {
+			group.Parallel = viper.GetInt(key + ".parallel")
+		}
+	}
+
+	if len(group.Profiles) == 0 {
+		return nil, fmt.Errorf("group '%s' has no profiles", name)
+	}
+	if group.Parallel < 1 {
+		group.Parallel = 1
+	}
+
+	return group, nil
+}
+
+// GroupResult is the outcome of running one profile as part of a group.
+type GroupResult struct {
+	ProfileKey string
+	Err        error
+}
+
+// CommandRunner executes command against profile, reusing whatever the
+// caller uses to invoke restic for a single profile (shell out, dry-run,
+// etc). RunGroup only orchestrates which profiles run, when, and how many
+// at once.
+type CommandRunner func(profileKey string, profile *Profile, command string) error
+
+// RunGroup runs command against every profile in the group, honouring
+// group.Parallel (a bounded worker pool; 1 means strictly sequential) and
+// group.ContinueOnError. As soon as a profile fails with ContinueOnError
+// false, every profile that hasn't started yet is recorded as aborted
+// instead of being run.
+func RunGroup(group *Group, command string, run CommandRunner) []GroupResult {
+	results := make([]GroupResult, len(group.Profiles))
+
+	parallel := group.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var aborted int32
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, profileKey := range group.Profiles {
+		if atomic.LoadInt32(&aborted) != 0 {
+			results[i] = GroupResult{ProfileKey: profileKey, Err: ErrGroupAborted}
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, profileKey string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if atomic.LoadInt32(&aborted) != 0 {
+				results[i] = GroupResult{ProfileKey: profileKey, Err: ErrGroupAborted}
+				return
+			}
+
+			err := runOne(profileKey, command, run)
+			results[i] = GroupResult{ProfileKey: profileKey, Err: err}
+			if err != nil && !group.ContinueOnError {
+				atomic.StoreInt32(&aborted, 1)
+			}
+		}(i, profileKey)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func runOne(profileKey, command string, run CommandRunner) error {
+	profile, err := LoadProfile(profileKey)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("unknown profile '%s'", profileKey)
+	}
+	return run(profileKey, profile, command)
+}
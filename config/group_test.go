@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadGroupConfig(t *testing.T, testConfig string) {
+	viper.Reset()
+	viper.SetConfigType("toml")
+	if err := viper.ReadConfig(bytes.NewBufferString(testConfig)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadGroupShorthand(t *testing.T) {
+	loadGroupConfig(t, `[groups]
+first = ["backup", "dev"]
+`)
+
+	group, err := LoadGroup("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"backup", "dev"}, group.Profiles)
+	assert.Equal(t, false, group.ContinueOnError)
+	assert.Equal(t, 1, group.Parallel)
+}
+
+func TestLoadGroupTableForm(t *testing.T) {
+	loadGroupConfig(t, `[groups.first]
+profiles = ["backup", "dev"]
+continue-on-error = true
+parallel = 3
+`)
+
+	group, err := LoadGroup("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"backup", "dev"}, group.Profiles)
+	assert.Equal(t, true, group.ContinueOnError)
+	assert.Equal(t, 3, group.Parallel)
+}
+
+func TestLoadUnknownGroup(t *testing.T) {
+	loadGroupConfig(t, `[groups]
+first = ["backup"]
+`)
+
+	_, err := LoadGroup("second")
+	assert.Error(t, err)
+}
+
+func TestRunGroupSequentialSuccess(t *testing.T) {
+	loadGroupConfig(t, `[backup]
+repository = "repo-backup"
+
+[dev]
+repository = "repo-dev"
+
+[groups]
+first = ["backup", "dev"]
+`)
+	group, err := LoadGroup("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	results := RunGroup(group, "backup", func(profileKey string, profile *Profile, command string) error {
+		order = append(order, profileKey)
+		return nil
+	})
+
+	assert.Equal(t, []string{"backup", "dev"}, order)
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.NoError(t, result.Err)
+	}
+}
+
+func TestRunGroupAbortsOnErrorByDefault(t *testing.T) {
+	loadGroupConfig(t, `[backup]
+[dev]
+[groups]
+first = ["backup", "dev"]
+`)
+	group, err := LoadGroup("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := RunGroup(group, "backup", func(profileKey string, profile *Profile, command string) error {
+		if profileKey == "backup" {
+			return errors.New("backup failed")
+		}
+		return nil
+	})
+
+	assert.Len(t, results, 2)
+	assert.Error(t, results[0].Err)
+	assert.Equal(t, ErrGroupAborted, results[1].Err)
+}
+
+func TestRunGroupContinuesOnError(t *testing.T) {
+	loadGroupConfig(t, `[backup]
+[dev]
+[groups.first]
+profiles = ["backup", "dev"]
+continue-on-error = true
+`)
+	group, err := LoadGroup("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := RunGroup(group, "backup", func(profileKey string, profile *Profile, command string) error {
+		if profileKey == "backup" {
+			return errors.New("backup failed")
+		}
+		return nil
+	})
+
+	assert.Len(t, results, 2)
+	assert.Error(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+}
+
+func TestRunGroupParallelPropagatesPerProfileValues(t *testing.T) {
+	loadGroupConfig(t, `[backup]
+repository = "repo-backup"
+
+[dev]
+repository = "repo-dev"
+
+[groups.first]
+profiles = ["backup", "dev"]
+parallel = 2
+`)
+	group, err := LoadGroup("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]string)
+	var mu sync.Mutex
+	results := RunGroup(group, "backup", func(profileKey string, profile *Profile, command string) error {
+		mu.Lock()
+		seen[profileKey] = profile.Repository
+		mu.Unlock()
+		return nil
+	})
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, "repo-backup", seen["backup"])
+	assert.Equal(t, "repo-dev", seen["dev"])
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatFromExtension(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"profiles.toml", "toml"},
+		{"profiles.yaml", "yaml"},
+		{"profiles.yml", "yaml"},
+		{"profiles.json", "json"},
+		{"profiles.hcl", "hcl"},
+		{"profiles.env", "env"},
+		{"/etc/resticprofile/profiles.TOML", "toml"},
+	}
+	for _, testCase := range testCases {
+		format, err := formatFromExtension(testCase.path)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, format)
+	}
+}
+
+func TestFormatFromExtensionUnknown(t *testing.T) {
+	_, err := formatFromExtension("profiles.ini")
+	assert.Error(t, err)
+}
+
+// loadEquivalent parses source in the given format and returns the fully
+// resolved "profile" Profile, so the same assertions can run against every
+// supported format.
+func loadEquivalent(t *testing.T, source, format string) *Profile {
+	viper.Reset()
+	err := LoadConfigurationFrom(bytes.NewBufferString(source), format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := LoadProfile("profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return profile
+}
+
+func assertReferenceProfile(t *testing.T, profile *Profile) {
+	assert := assert.New(t)
+	assert.NotNil(profile)
+	assert.Equal("profile", profile.Name)
+	assert.Equal(true, profile.Quiet)
+	assert.Equal(true, profile.Verbose)
+	assert.Equal("parent-repo", profile.Repository)
+	assert.Equal("/data", profile.Backup["source"])
+
+	groups := ProfileGroups()
+	assert.NotNil(groups)
+	assert.Equal([]string{"profile"}, groups["full"])
+}
+
+func TestLoadConfigurationFromTOML(t *testing.T) {
+	testConfig := `
+[parent]
+repository = "parent-repo"
+quiet = true
+
+[profile]
+inherit = "parent"
+verbose = true
+
+[profile.backup]
+source = "/data"
+
+[groups]
+full = ["profile"]
+`
+	assertReferenceProfile(t, loadEquivalent(t, testConfig, "toml"))
+}
+
+func TestLoadConfigurationFromYAML(t *testing.T) {
+	testConfig := `
+parent:
+  repository: parent-repo
+  quiet: true
+profile:
+  inherit: parent
+  verbose: true
+  backup:
+    source: /data
+groups:
+  full:
+    - profile
+`
+	assertReferenceProfile(t, loadEquivalent(t, testConfig, "yaml"))
+}
+
+func TestLoadConfigurationFromJSON(t *testing.T) {
+	testConfig := `
+{
+  "parent": {
+    "repository": "parent-repo",
+    "quiet": true
+  },
+  "profile": {
+    "inherit": "parent",
+    "verbose": true,
+    "backup": {
+      "source": "/data"
+    }
+  },
+  "groups": {
+    "full": ["profile"]
+  }
+}
+`
+	assertReferenceProfile(t, loadEquivalent(t, testConfig, "json"))
+}
+
+func TestLoadConfigurationFromHCL(t *testing.T) {
+	testConfig := `
+parent {
+  repository = "parent-repo"
+  quiet = true
+}
+profile {
+  inherit = "parent"
+  verbose = true
+  backup {
+    source = "/data"
+  }
+}
+groups {
+  full = ["profile"]
+}
+`
+	assertReferenceProfile(t, loadEquivalent(t, testConfig, "hcl"))
+}
+
+func TestLoadConfigurationFromReaderUnmarshalsFlatEnv(t *testing.T) {
+	// dotenv has no notion of nested tables: keys carry the profile name
+	// as a dotted prefix and every value is a plain string, so this uses
+	// a profile made of flat keys only.
+	testConfig := "profile.quiet=true\nprofile.repository=flat-repo\n"
+
+	viper.Reset()
+	err := LoadConfigurationFrom(bytes.NewBufferString(testConfig), "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := LoadProfile("profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+	assert.Equal(t, true, profile.Quiet)
+	assert.Equal(t, "flat-repo", profile.Repository)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"io"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// configMu guards every read of the global viper configuration (LoadProfile,
+// ProfileKeys, ProfileSections, ProfileGroups, LoadGroup) against a
+// concurrent reload replacing it out from under them - whether that reload
+// comes from config.Watch or remote.WatchRemote. Both funnel their reload
+// through ReloadProfile, so they can't race each other either.
+var configMu sync.RWMutex
+
+// ReloadProfile atomically replaces the global configuration with the
+// content read from reader (parsed as format) and returns profileKey
+// freshly resolved from it. If parsing or loading profileKey fails, the
+// previous configuration is left exactly as it was. The whole
+// replace-then-read sequence holds the same lock as LoadProfile and
+// friends, so no reader can ever observe a half-replaced configuration.
+func ReloadProfile(reader io.Reader, format, profileKey string) (*Profile, error) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	previous := viper.AllSettings()
+
+	viper.Reset()
+	if err := LoadConfigurationFrom(reader, format); err != nil {
+		viper.Reset()
+		_ = viper.MergeConfigMap(previous)
+		return nil, err
+	}
+
+	profile, err := loadProfileLocked(profileKey)
+	if err != nil {
+		viper.Reset()
+		_ = viper.MergeConfigMap(previous)
+		return nil, err
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadProfileReplacesConfiguration(t *testing.T) {
+	viper.Reset()
+	_, err := getProfile(`[profile]
+repository = "file:///initial"
+`, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := ReloadProfile(strings.NewReader(`[profile]
+repository = "file:///updated"
+`), "toml", "profile")
+	assert.NoError(t, err)
+	assert.Equal(t, "file:///updated", profile.Repository)
+
+	reloaded, err := LoadProfile("profile")
+	assert.NoError(t, err)
+	assert.Equal(t, "file:///updated", reloaded.Repository)
+}
+
+func TestReloadProfileKeepsPreviousConfigurationOnError(t *testing.T) {
+	viper.Reset()
+	_, err := getProfile(`[profile]
+repository = "file:///good"
+`, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ReloadProfile(strings.NewReader("not valid toml [[["), "toml", "profile")
+	assert.Error(t, err)
+
+	profile, err := LoadProfile("profile")
+	assert.NoError(t, err)
+	assert.Equal(t, "file:///good", profile.Repository)
+}
+
+// TestConcurrentLoadAndReloadProfile exercises LoadProfile and
+// ReloadProfile from many goroutines at once: run with -race, a missing
+// configMu lock around either one would show up as a data race on
+// viper's internal state.
+func TestConcurrentLoadAndReloadProfile(t *testing.T) {
+	viper.Reset()
+	_, err := getProfile(`[profile]
+repository = "file:///initial"
+`, "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = LoadProfile("profile")
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = ReloadProfile(strings.NewReader(`[profile]
+repository = "file:///reloaded"
+`), "toml", "profile")
+		}()
+	}
+	wg.Wait()
+}
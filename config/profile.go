@@ -0,0 +1,263 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/constants"
+	"github.com/spf13/viper"
+)
+
+// Profile is the runtime representation of a single [profile] section of
+// the configuration, fully resolved through the `inherit` chain.
+type Profile struct {
+	Name       string                 `mapstructure:"-"`
+	Quiet      bool                   `mapstructure:"quiet"`
+	Verbose    bool                   `mapstructure:"verbose"`
+	Repository string                 `mapstructure:"repository"`
+	Initialize bool                   `mapstructure:"initialize"`
+	Inherit    string                 `mapstructure:"inherit"`
+	Backup     map[string]interface{} `mapstructure:"backup"`
+	Retention  map[string]interface{} `mapstructure:"retention"`
+	Snapshots  map[string]interface{} `mapstructure:"snapshots"`
+	Check      map[string]interface{} `mapstructure:"check"`
+	Forget     map[string]interface{} `mapstructure:"forget"`
+	Prune      map[string]interface{} `mapstructure:"prune"`
+	Mount      map[string]interface{} `mapstructure:"mount"`
+	OtherFlags map[string]interface{} `mapstructure:",remain"`
+
+	host        string
+	envBindings map[string][]string
+}
+
+// LoadProfile reads the profile named profileKey from the currently loaded
+// viper configuration, resolving any `inherit` chain. It returns a nil
+// profile (and a nil error) when no such key is set at all.
+func LoadProfile(profileKey string) (*Profile, error) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return loadProfileLocked(profileKey)
+}
+
+// loadProfileLocked is LoadProfile without acquiring configMu, for callers
+// (ReloadProfile) that already hold it.
+func loadProfileLocked(profileKey string) (*Profile, error) {
+	if !viper.IsSet(profileKey) {
+		return nil, nil
+	}
+	return loadProfile(profileKey, make(map[string]bool))
+}
+
+func loadProfile(profileKey string, seen map[string]bool) (*Profile, error) {
+	if seen[profileKey] {
+		return nil, fmt.Errorf("circular inheritance detected on profile '%s'", profileKey)
+	}
+	seen[profileKey] = true
+
+	profile := &Profile{Name: profileKey}
+	if err := viper.UnmarshalKey(profileKey, profile); err != nil {
+		return nil, err
+	}
+	profile.Name = profileKey
+
+	if profile.Inherit != "" {
+		if !viper.IsSet(profile.Inherit) {
+			return nil, fmt.Errorf("profile '%s' inherits from unknown profile '%s'", profileKey, profile.Inherit)
+		}
+		parent, err := loadProfile(profile.Inherit, seen)
+		if err != nil {
+			return nil, err
+		}
+		profile = mergeProfile(profileKey, profile, parent)
+	}
+
+	return profile, nil
+}
+
+// mergeProfile fills in any field that profileKey didn't explicitly set
+// itself with the already-resolved value from its parent.
+func mergeProfile(profileKey string, profile, parent *Profile) *Profile {
+	if !viper.IsSet(profileKey + ".quiet") {
+		profile.Quiet = parent.Quiet
+	}
+	if !viper.IsSet(profileKey + ".verbose") {
+		profile.Verbose = parent.Verbose
+	}
+	if !viper.IsSet(profileKey + ".initialize") {
+		profile.Initialize = parent.Initialize
+	}
+	if !viper.IsSet(profileKey + ".repository") {
+		profile.Repository = parent.Repository
+	}
+
+	profile.Backup = mergeSection(profileKey, "backup", profile.Backup, parent.Backup)
+	profile.Retention = mergeSection(profileKey, "retention", profile.Retention, parent.Retention)
+	profile.Snapshots = mergeSection(profileKey, "snapshots", profile.Snapshots, parent.Snapshots)
+	profile.Check = mergeSection(profileKey, "check", profile.Check, parent.Check)
+	profile.Forget = mergeSection(profileKey, "forget", profile.Forget, parent.Forget)
+	profile.Prune = mergeSection(profileKey, "prune", profile.Prune, parent.Prune)
+	profile.Mount = mergeSection(profileKey, "mount", profile.Mount, parent.Mount)
+
+	profile.OtherFlags = mergeOtherFlags(parent.OtherFlags, profile.OtherFlags)
+
+	return profile
+}
+
+func mergeSection(profileKey, name string, section, parentSection map[string]interface{}) map[string]interface{} {
+	if !viper.IsSet(profileKey + "." + name) {
+		return parentSection
+	}
+	return section
+}
+
+func mergeOtherFlags(parent, child map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(parent)+len(child))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range child {
+		merged[key] = value
+	}
+	return merged
+}
+
+// SetHost records the host name to use whenever a command section turns
+// on `host = true` instead of providing an explicit host string.
+func (p *Profile) SetHost(host string) {
+	p.host = host
+}
+
+// GetCommonFlags returns the flags shared by every restic command: the
+// profile's own OtherFlags plus quiet, verbose and the repository.
+func (p *Profile) GetCommonFlags() map[string][]string {
+	p.resolveEnvOverrides()
+
+	flags := make(map[string][]string)
+	addFlags(flags, p.OtherFlags, "")
+
+	if p.Quiet {
+		flags["quiet"] = []string{}
+	}
+	if p.Verbose {
+		flags["verbose"] = []string{}
+	}
+	if p.Repository != "" {
+		flags["repo"] = []string{p.Repository}
+	}
+	return flags
+}
+
+// GetCommandFlags returns the flags for a particular restic command,
+// combining the common flags with the command's own section.
+func (p *Profile) GetCommandFlags(command string) map[string][]string {
+	flags := p.GetCommonFlags()
+	addFlags(flags, p.getSection(command), p.host)
+	return flags
+}
+
+// GetRetentionFlags returns the flags to use when running `restic forget`
+// for this profile's retention policy. When the retention section doesn't
+// set an explicit `path`, it defaults to the backup section's `source`.
+func (p *Profile) GetRetentionFlags() map[string][]string {
+	flags := p.GetCommonFlags()
+	addFlags(flags, p.Retention, p.host)
+
+	if _, ok := flags["path"]; !ok {
+		if source, ok := p.Backup["source"]; ok {
+			addFlag(flags, "path", source)
+		}
+	}
+	return flags
+}
+
+func (p *Profile) getSection(command string) map[string]interface{} {
+	switch command {
+	case constants.CommandBackup:
+		return p.Backup
+	case constants.CommandSnapshots:
+		return p.Snapshots
+	case constants.CommandCheck:
+		return p.Check
+	case constants.CommandForget:
+		return p.Forget
+	case constants.CommandPrune:
+		return p.Prune
+	case constants.CommandMount:
+		return p.Mount
+	default:
+		return nil
+	}
+}
+
+// addFlags converts a generic configuration section into shell-style flags,
+// merging the result into flags. `host` receives special treatment:
+// `host = true` resolves to the host name passed in (and adds no flag when
+// that is empty) instead of becoming a valueless flag like any other true
+// boolean.
+func addFlags(flags map[string][]string, section map[string]interface{}, host string) {
+	for key, value := range section {
+		if key == "host" {
+			if enabled, ok := value.(bool); ok {
+				if enabled && host != "" {
+					flags["host"] = []string{host}
+				}
+				continue
+			}
+		}
+		addFlag(flags, key, value)
+	}
+}
+
+func addFlag(flags map[string][]string, key string, value interface{}) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			flags[key] = []string{}
+		}
+	case string:
+		if v != "" {
+			flags[key] = []string{v}
+		}
+	case int:
+		if v != 0 {
+			flags[key] = []string{fmt.Sprintf("%d", v)}
+		}
+	case int64:
+		if v != 0 {
+			flags[key] = []string{fmt.Sprintf("%d", v)}
+		}
+	case float64:
+		if v != 0 {
+			flags[key] = []string{fmt.Sprintf("%f", v)}
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				values = append(values, fmt.Sprintf("%v", item))
+			}
+			flags[key] = values
+		}
+	}
+}
+
+// fixPath expands `$VAR` environment references in path, then turns a
+// relative result into one rooted at prefix. Absolute and home-relative
+// (`~/...`) results are returned as expanded; a path containing a `%`
+// (an unresolved Windows-style `%VAR%` reference) is returned unchanged.
+func fixPath(path, prefix string) string {
+	if path == "" {
+		return path
+	}
+
+	expanded := os.ExpandEnv(path)
+	if strings.ContainsRune(expanded, '%') {
+		return path
+	}
+	if filepath.IsAbs(expanded) || strings.HasPrefix(expanded, "~") {
+		return expanded
+	}
+	return filepath.Join(prefix, expanded)
+}
@@ -0,0 +1,155 @@
+// Package remote loads profile configuration from a centrally managed KV
+// store (consul, etcd3) or a plain HTTPS endpoint, feeding it through the
+// same parsing pipeline as a local file (see config.LoadConfigurationFrom).
+package remote
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/creativeprojects/resticprofile/config"
+)
+
+// Provider identifies a supported remote configuration backend.
+type Provider string
+
+// Supported remote configuration providers.
+const (
+	ProviderConsul Provider = "consul"
+	ProviderEtcd3  Provider = "etcd3"
+	ProviderHTTPS  Provider = "https"
+)
+
+type options struct {
+	token string
+}
+
+// Option customises how the remote backend is queried.
+type Option func(*options)
+
+// WithToken sets the authentication token sent to the backend: a bearer
+// token for https, an ACL token for consul, or an auth token for etcd3.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// LoadRemote fetches path from endpoint through provider and loads it into
+// the global configuration using format, exactly like config.LoadConfiguration
+// does for a local file: inheritance, groups and sections all work the
+// same way afterwards.
+func LoadRemote(provider, endpoint, path, format string, opts ...Option) error {
+	data, err := fetch(provider, endpoint, path, opts...)
+	if err != nil {
+		return err
+	}
+	return config.LoadConfigurationFrom(bytes.NewReader(data), format)
+}
+
+func fetch(provider, endpoint, path string, opts ...Option) ([]byte, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	switch Provider(provider) {
+	case ProviderHTTPS:
+		return fetchHTTPS(endpoint, path, o)
+	case ProviderConsul:
+		return fetchConsul(endpoint, path, o)
+	case ProviderEtcd3:
+		return fetchEtcd3(endpoint, path, o)
+	default:
+		return nil, fmt.Errorf("unsupported remote configuration provider '%s'", provider)
+	}
+}
+
+func fetchHTTPS(endpoint, path string, o *options) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, joinURL(endpoint, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.token != "" {
+		request.Header.Set("Authorization", "Bearer "+o.token)
+	}
+	return doRequest(request)
+}
+
+func fetchConsul(endpoint, path string, o *options) ([]byte, error) {
+	url := joinURL(endpoint, "v1/kv/"+strings.TrimLeft(path, "/")) + "?raw=true"
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.token != "" {
+		request.Header.Set("X-Consul-Token", o.token)
+	}
+	return doRequest(request)
+}
+
+type etcd3RangeResponse struct {
+	Kvs []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"kvs"`
+}
+
+func fetchEtcd3(endpoint, path string, o *options) ([]byte, error) {
+	body, err := json.Marshal(map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(path)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, joinURL(endpoint, "v3/kv/range"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if o.token != "" {
+		request.Header.Set("Authorization", "Bearer "+o.token)
+	}
+
+	raw, err := doRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response etcd3RangeResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, fmt.Errorf("cannot parse etcd3 response: %w", err)
+	}
+	if len(response.Kvs) == 0 {
+		return nil, fmt.Errorf("key '%s' not found in etcd3", path)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(response.Kvs[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode etcd3 value: %w", err)
+	}
+	return value, nil
+}
+
+func doRequest(request *http.Request) ([]byte, error) {
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s fetching %s", response.Status, request.URL)
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+func joinURL(endpoint, path string) string {
+	return strings.TrimRight(endpoint, "/") + "/" + strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,187 @@
+package remote
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRemoteHTTPS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret-token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `
+[profile]
+repository = "s3:remote-repo"
+`)
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	err := LoadRemote("https", server.URL, "/profiles.toml", "toml", WithToken("secret-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := config.LoadProfile("profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+	assert.Equal(t, "s3:remote-repo", profile.Repository)
+}
+
+func TestLoadRemoteHTTPSYAML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "profile:\n  repository: s3:remote-repo-yaml\n")
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	err := LoadRemote("https", server.URL, "/profiles.yaml", "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := config.LoadProfile("profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+	assert.Equal(t, "s3:remote-repo-yaml", profile.Repository)
+}
+
+func TestLoadRemoteConsul(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/resticprofile/profiles", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("raw"))
+		assert.Equal(t, "acl-token", r.Header.Get("X-Consul-Token"))
+		fmt.Fprint(w, `[profile]
+repository = "s3:consul-repo"
+`)
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	err := LoadRemote("consul", server.URL, "resticprofile/profiles", "toml", WithToken("acl-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := config.LoadProfile("profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+	assert.Equal(t, "s3:consul-repo", profile.Repository)
+}
+
+func TestLoadRemoteEtcd3(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/kv/range", r.URL.Path)
+		value := base64.StdEncoding.EncodeToString([]byte(`[profile]
+repository = "s3:etcd-repo"
+`))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kvs": []map[string]string{
+				{"key": base64.StdEncoding.EncodeToString([]byte("resticprofile/profiles")), "value": value},
+			},
+		})
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	err := LoadRemote("etcd3", server.URL, "resticprofile/profiles", "toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := config.LoadProfile("profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, profile)
+	assert.Equal(t, "s3:etcd-repo", profile.Repository)
+}
+
+func TestLoadRemoteUnknownProvider(t *testing.T) {
+	viper.Reset()
+	err := LoadRemote("sftp", "http://example.com", "path", "toml")
+	assert.Error(t, err)
+}
+
+func TestWatchRemoteReloadsOnChange(t *testing.T) {
+	var repository atomic.Value
+	repository.Store("s3:initial")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[profile]\nrepository = \"%s\"\n", repository.Load().(string))
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	changes := make(chan *config.Profile, 2)
+	err := WatchRemote("https", server.URL, "/profiles.toml", "toml", "profile", 30*time.Millisecond,
+		func(profile *config.Profile, err error) {
+			if err == nil {
+				changes <- profile
+			}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StopRemoteWatch()
+
+	repository.Store("s3:updated")
+
+	select {
+	case profile := <-changes:
+		assert.Contains(t, []string{"s3:updated"}, profile.Repository)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for remote reload")
+	}
+
+	assert.Equal(t, "s3:updated", WatchedRemoteProfile().Repository)
+}
+
+// TestWatchRemoteSkipsReloadWhenUnchanged makes sure a poll that fetches
+// byte-for-byte identical content doesn't fire onChange again - reload is
+// an on-change hook, not a per-tick one.
+func TestWatchRemoteSkipsReloadWhenUnchanged(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, "[profile]\nrepository = \"s3:stable\"\n")
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	changes := make(chan *config.Profile, 10)
+	err := WatchRemote("https", server.URL, "/profiles.toml", "toml", "profile", 20*time.Millisecond,
+		func(profile *config.Profile, err error) {
+			if err == nil {
+				changes <- profile
+			}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StopRemoteWatch()
+
+	// Give the poller time for several ticks against unchanged content.
+	time.Sleep(200 * time.Millisecond)
+	assert.GreaterOrEqual(t, int(atomic.LoadInt32(&requests)), 2)
+
+	select {
+	case profile := <-changes:
+		t.Fatalf("onChange fired for unchanged content: %+v", profile)
+	default:
+	}
+}
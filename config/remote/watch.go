@@ -0,0 +1,128 @@
+package remote
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/config"
+)
+
+// defaultRefreshInterval is used by WatchRemote when ttl <= 0.
+const defaultRefreshInterval = 30 * time.Second
+
+type remoteWatch struct {
+	mu      sync.RWMutex
+	stop    chan struct{}
+	profile *config.Profile
+	lastRaw []byte
+}
+
+// activeWatchMu guards activeRemoteWatch itself (as opposed to the fields
+// inside it, which remoteWatch.mu guards) against concurrent
+// WatchRemote/StopRemoteWatch/WatchedRemoteProfile calls.
+var activeWatchMu sync.RWMutex
+var activeRemoteWatch *remoteWatch
+
+// WatchRemote polls the remote backend every ttl and reloads profileKey
+// whenever the fetched configuration changes, calling onChange with the
+// freshly resolved profile. It mirrors config.Watch's contract: a failed
+// reload calls onChange with a nil profile and an error, and leaves the
+// previously loaded configuration untouched.
+func WatchRemote(provider, endpoint, path, format, profileKey string, ttl time.Duration, onChange func(*config.Profile, error), opts ...Option) error {
+	if ttl <= 0 {
+		ttl = defaultRefreshInterval
+	}
+
+	w := &remoteWatch{stop: make(chan struct{})}
+	if raw, err := fetch(provider, endpoint, path, opts...); err == nil {
+		if profile, err := config.ReloadProfile(bytes.NewReader(raw), format, profileKey); err == nil {
+			w.lastRaw = raw
+			w.profile = profile
+		}
+	}
+
+	activeWatchMu.Lock()
+	activeRemoteWatch = w
+	activeWatchMu.Unlock()
+
+	go w.run(provider, endpoint, path, format, profileKey, ttl, onChange, opts)
+	return nil
+}
+
+// StopRemoteWatch releases the polling loop started by WatchRemote. It's a
+// no-op if WatchRemote was never called, or was already stopped.
+func StopRemoteWatch() {
+	activeWatchMu.Lock()
+	defer activeWatchMu.Unlock()
+
+	if activeRemoteWatch == nil {
+		return
+	}
+	close(activeRemoteWatch.stop)
+	activeRemoteWatch = nil
+}
+
+// WatchedRemoteProfile returns the most recently loaded profile for the
+// active WatchRemote call, or nil if WatchRemote hasn't been called.
+func WatchedRemoteProfile() *config.Profile {
+	activeWatchMu.RLock()
+	w := activeRemoteWatch
+	activeWatchMu.RUnlock()
+
+	if w == nil {
+		return nil
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.profile
+}
+
+func (w *remoteWatch) run(provider, endpoint, path, format, profileKey string, ttl time.Duration, onChange func(*config.Profile, error), opts []Option) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.reload(provider, endpoint, path, format, profileKey, onChange, opts)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// reload fetches the remote configuration and, only if its raw content
+// differs from what's already loaded, reparses it through
+// config.ReloadProfile (so it can't race a concurrent config.Watch reload
+// or a LoadProfile call) and calls onChange. A tick that fetches
+// unchanged content is a no-op, matching the doc comment on WatchRemote:
+// onChange fires when the fetched configuration changes, not on every
+// poll.
+func (w *remoteWatch) reload(provider, endpoint, path, format, profileKey string, onChange func(*config.Profile, error), opts []Option) {
+	raw, err := fetch(provider, endpoint, path, opts...)
+	if err != nil {
+		onChange(nil, err)
+		return
+	}
+
+	w.mu.RLock()
+	unchanged := bytes.Equal(raw, w.lastRaw)
+	w.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	profile, err := config.ReloadProfile(bytes.NewReader(raw), format, profileKey)
+	if err != nil {
+		onChange(nil, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.profile = profile
+	w.lastRaw = raw
+	w.mu.Unlock()
+
+	onChange(profile, nil)
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/creativeprojects/resticprofile/clog"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces the burst of write events most editors generate
+// for a single save into one reload.
+const watchDebounce = 200 * time.Millisecond
+
+// watch holds the state of the single active Watch call. resticprofile
+// only ever watches one configuration file at a time.
+type watch struct {
+	mu      sync.RWMutex
+	watcher *fsnotify.Watcher
+	stop    chan struct{}
+	profile *Profile
+}
+
+var activeWatch *watch
+
+// Watch loads path and starts watching it for changes, re-parsing
+// profileKey every time the file is written to and calling onChange with
+// the freshly loaded profile. If the rewritten file fails to parse,
+// onChange is called with a nil profile and the error, and the previously
+// loaded profile is left untouched.
+func Watch(path, profileKey string, onChange func(*Profile, error)) error {
+	if activeWatch != nil {
+		return fmt.Errorf("already watching a configuration file, call StopWatch first")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("cannot start configuration watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("cannot watch '%s': %w", path, err)
+	}
+
+	current, err := reloadProfileFrom(path, profileKey)
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("cannot load '%s': %w", path, err)
+	}
+
+	w := &watch{
+		watcher: watcher,
+		stop:    make(chan struct{}),
+		profile: current,
+	}
+	activeWatch = w
+
+	go w.run(path, profileKey, onChange)
+	return nil
+}
+
+// StopWatch releases the watcher started by Watch. It's a no-op if Watch
+// was never called, or was already stopped.
+func StopWatch() {
+	if activeWatch == nil {
+		return
+	}
+	close(activeWatch.stop)
+	activeWatch.watcher.Close()
+	activeWatch = nil
+}
+
+func (w *watch) run(path, profileKey string, onChange func(*Profile, error)) {
+	reload := make(chan struct{}, 1)
+	var debounce *time.Timer
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(path) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(watchDebounce, func() {
+				select {
+				case reload <- struct{}{}:
+				default:
+				}
+			})
+
+		case <-reload:
+			w.reloadProfile(path, profileKey, onChange)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			clog.Errorf("error watching '%s': %v", path, err)
+			onChange(nil, err)
+
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// reloadProfile atomically re-parses path into the global configuration
+// and loads profileKey again, via the same configMu-guarded ReloadProfile
+// used by remote.WatchRemote, so a local file reload and a remote poll can
+// never race each other or be observed half-merged by a concurrent
+// LoadProfile call. On any failure, the previous configuration (and
+// cached profile) is restored so a broken edit can't clobber a good one.
+func (w *watch) reloadProfile(path, profileKey string, onChange func(*Profile, error)) {
+	profile, err := reloadProfileFrom(path, profileKey)
+	if err != nil {
+		onChange(nil, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.profile = profile
+	w.mu.Unlock()
+
+	onChange(profile, nil)
+}
+
+func reloadProfileFrom(path, profileKey string) (*Profile, error) {
+	format, err := formatFromExtension(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open configuration file: %w", err)
+	}
+	defer file.Close()
+
+	return ReloadProfile(file, format, profileKey)
+}
+
+// WatchedProfile returns the most recently loaded profile for the active
+// Watch call, or nil if Watch hasn't been called.
+func WatchedProfile() *Profile {
+	if activeWatch == nil {
+		return nil
+	}
+	activeWatch.mu.RLock()
+	defer activeWatch.mu.RUnlock()
+	return activeWatch.profile
+}
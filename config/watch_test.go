@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchReloadsOnWrite(t *testing.T) {
+	viper.Reset()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "profiles.toml")
+
+	initial := "[profile]\ninitialize = false\nrepository = \"file:///initial\"\n"
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan *Profile, 1)
+	errs := make(chan error, 1)
+	err := Watch(path, "profile", func(profile *Profile, err error) {
+		if err != nil {
+			errs <- err
+			return
+		}
+		changes <- profile
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StopWatch()
+
+	updated := "[profile]\ninitialize = true\nrepository = \"file:///updated\"\n"
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case profile := <-changes:
+		assert.Equal(t, true, profile.Initialize)
+		assert.Equal(t, "file:///updated", profile.Repository)
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for config reload")
+	}
+}
+
+func TestWatchKeepsPreviousProfileOnBrokenConfig(t *testing.T) {
+	viper.Reset()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "profiles.toml")
+
+	initial := "[profile]\nrepository = \"file:///good\"\n"
+	if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan *Profile, 1)
+	errs := make(chan error, 1)
+	err := Watch(path, "profile", func(profile *Profile, err error) {
+		if err != nil {
+			errs <- err
+			return
+		}
+		changes <- profile
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer StopWatch()
+
+	broken := "not valid toml [[["
+	if err := os.WriteFile(path, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case profile := <-changes:
+		t.Fatalf("expected an error, got a profile: %+v", profile)
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for config reload error")
+	}
+
+	profile := WatchedProfile()
+	assert.NotNil(t, profile)
+	assert.Equal(t, "file:///good", profile.Repository)
+}
@@ -0,0 +1,24 @@
+package constants
+
+// Names of the restic commands supported in a profile configuration
+const (
+	CommandInit      = "init"
+	CommandBackup    = "backup"
+	CommandSnapshots = "snapshots"
+	CommandForget    = "forget"
+	CommandCheck     = "check"
+	CommandPrune     = "prune"
+	CommandMount     = "mount"
+)
+
+// SectionConfigurationGlobal is the name of the [global] section in a configuration file
+const SectionConfigurationGlobal = "global"
+
+// SectionConfigurationGroups is the name of the [groups] section in a configuration file
+const SectionConfigurationGroups = "groups"
+
+// DefaultConfigurationFile is the file name used when none is specified on the command line
+const DefaultConfigurationFile = "profiles.conf"
+
+// DefaultTheme is the default console output theme
+const DefaultTheme = "light"
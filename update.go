@@ -1,17 +1,37 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/blang/semver"
 	"github.com/creativeprojects/resticprofile/clog"
 	"github.com/rhysd/go-github-selfupdate/selfupdate"
 )
 
-func confirmAndSelfUpdate(debug bool) error {
+// maintainerPublicKeyArmored pins the maintainer's OpenPGP public key used
+// to verify SHA256SUMS.asc. It's left empty in this source tree (there's
+// no release signing key checked in yet); when empty, signature
+// verification is skipped and only the checksum is enforced.
+var maintainerPublicKeyArmored = ""
+
+func confirmAndSelfUpdate(debug, noVerify, checkOnly bool) error {
 	if debug {
 		selfupdate.EnableLog()
 	}
@@ -26,6 +46,11 @@ func confirmAndSelfUpdate(debug bool) error {
 		return nil
 	}
 
+	if checkOnly {
+		clog.Infof("Version %s is available (current version is %s)", latest.Version, resticProfileVersion)
+		return nil
+	}
+
 	fmt.Print("Do you want to update to", latest.Version, "? (y/n): ")
 	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
 	if err != nil || (input != "y\n" && input != "n\n") {
@@ -39,9 +64,234 @@ func confirmAndSelfUpdate(debug bool) error {
 	if err != nil {
 		return errors.New("Could not locate executable path")
 	}
-	if err := selfupdate.UpdateTo(latest.AssetURL, exe); err != nil {
-		return fmt.Errorf("Error occurred while updating binary: %v", err)
+
+	asset, err := downloadFile(latest.AssetURL)
+	if err != nil {
+		return fmt.Errorf("Error occurred while downloading asset: %v", err)
+	}
+
+	if !noVerify {
+		if err := verifyAsset(latest.AssetURL, asset); err != nil {
+			return fmt.Errorf("release verification failed: %w", err)
+		}
+	} else {
+		clog.Warningf("skipping release verification (--no-verify)")
+	}
+
+	binary, err := extractAsset(path.Base(latest.AssetURL), asset, filepath.Base(exe))
+	if err != nil {
+		return fmt.Errorf("Error occurred while extracting asset: %w", err)
 	}
+
+	if err := stageAndReplace(exe, binary); err != nil {
+		return fmt.Errorf("Error occurred while updating binary: %w", err)
+	}
+
 	clog.Infof("Successfully updated to version %s", latest.Version)
 	return nil
 }
+
+// verifyAsset fetches the release's SHA256SUMS (and SHA256SUMS.asc when a
+// maintainer public key is pinned) and checks that asset matches the
+// checksum recorded for it.
+func verifyAsset(assetURL string, asset []byte) error {
+	sumsURL := siblingAssetURL(assetURL, "SHA256SUMS")
+
+	sums, err := downloadFile(sumsURL)
+	if err != nil {
+		return fmt.Errorf("cannot download SHA256SUMS: %w", err)
+	}
+
+	if maintainerPublicKeyArmored != "" {
+		sigURL := siblingAssetURL(assetURL, "SHA256SUMS.asc")
+		signature, err := downloadFile(sigURL)
+		if err != nil {
+			return fmt.Errorf("cannot download SHA256SUMS.asc: %w", err)
+		}
+		if err := verifySignature(sums, signature); err != nil {
+			return fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+
+	return verifyChecksum(sums, path.Base(assetURL), asset)
+}
+
+// verifyChecksum looks up assetName in a SHA256SUMS-formatted file
+// ("<hex digest>  <filename>" per line) and compares it against the
+// actual digest of asset.
+func verifyChecksum(sums []byte, assetName string, asset []byte) error {
+	expected := ""
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if fields[1] == assetName || strings.TrimPrefix(fields[1], "*") == assetName {
+			expected = strings.ToLower(fields[0])
+			break
+		}
+	}
+	if expected == "" {
+		return fmt.Errorf("no checksum found for '%s' in SHA256SUMS", assetName)
+	}
+
+	sum := sha256.Sum256(asset)
+	actual := hex.EncodeToString(sum[:])
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for '%s': expected %s, got %s", assetName, expected, actual)
+	}
+	return nil
+}
+
+// siblingAssetURL returns the URL of another asset (e.g. SHA256SUMS)
+// published alongside assetURL, by replacing its last path segment with
+// name. It goes through net/url rather than path.Join/path.Dir, which
+// would collapse the "//" after the URL scheme.
+func siblingAssetURL(assetURL, name string) string {
+	u, err := url.Parse(assetURL)
+	if err != nil {
+		return path.Join(path.Dir(assetURL), name)
+	}
+	u.Path = path.Join(path.Dir(u.Path), name)
+	return u.String()
+}
+
+func downloadFile(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, url)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// extractAsset returns the exeName binary out of the downloaded release
+// asset. goreleaser-built assets ship as a .tar.gz or .zip archive
+// containing the binary alongside the licence/readme, so assetName (the
+// asset's own file name, used to detect its format) determines how to
+// unpack data; anything else is assumed to already be the raw binary.
+func extractAsset(assetName string, data []byte, exeName string) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(assetName, ".tar.gz"), strings.HasSuffix(assetName, ".tgz"):
+		return extractFromTarGz(data, exeName)
+	case strings.HasSuffix(assetName, ".zip"):
+		return extractFromZip(data, exeName)
+	default:
+		return data, nil
+	}
+}
+
+func extractFromTarGz(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || filepath.Base(header.Name) != name {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read '%s' from archive: %w", name, err)
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("'%s' not found in archive", name)
+}
+
+func extractFromZip(data []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open zip archive: %w", err)
+	}
+	for _, file := range zr.File {
+		if filepath.Base(file.Name) != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("cannot open '%s' in archive: %w", name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read '%s' from archive: %w", name, err)
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("'%s' not found in archive", name)
+}
+
+// stageAndReplace writes newBinary to a sibling temp file, fsyncs it,
+// then renames exe to "<exe>.bak" and renames the staged file to exe.
+// Each rename is atomic but the swap as a whole is not: between the two
+// renames no file exists at exe. If the new binary fails a basic sanity
+// check, the backup is restored and the update is rolled back.
+func stageAndReplace(exe string, newBinary []byte) error {
+	dir := filepath.Dir(exe)
+	staged, err := ioutil.TempFile(dir, filepath.Base(exe)+".new-*")
+	if err != nil {
+		return fmt.Errorf("cannot create staging file: %w", err)
+	}
+	stagedPath := staged.Name()
+	defer os.Remove(stagedPath)
+
+	if _, err := staged.Write(newBinary); err != nil {
+		staged.Close()
+		return fmt.Errorf("cannot write staging file: %w", err)
+	}
+	if err := staged.Sync(); err != nil {
+		staged.Close()
+		return fmt.Errorf("cannot fsync staging file: %w", err)
+	}
+	if err := staged.Close(); err != nil {
+		return fmt.Errorf("cannot close staging file: %w", err)
+	}
+	if err := os.Chmod(stagedPath, 0755); err != nil {
+		return fmt.Errorf("cannot make staged binary executable: %w", err)
+	}
+
+	backupPath := exe + ".bak"
+	if err := os.Rename(exe, backupPath); err != nil {
+		return fmt.Errorf("cannot back up current binary: %w", err)
+	}
+
+	if err := os.Rename(stagedPath, exe); err != nil {
+		// best effort: put the original binary back where it was
+		os.Rename(backupPath, exe)
+		return fmt.Errorf("cannot install new binary: %w", err)
+	}
+
+	if err := sanityCheck(exe); err != nil {
+		clog.Warningf("new binary failed sanity check, rolling back: %v", err)
+		if rollbackErr := os.Rename(backupPath, exe); rollbackErr != nil {
+			return fmt.Errorf("update failed (%v) and rollback failed (%v)", err, rollbackErr)
+		}
+		return fmt.Errorf("update failed sanity check and was rolled back: %w", err)
+	}
+
+	return nil
+}
+
+// sanityCheck runs "<exe> --version" to make sure the freshly installed
+// binary at least starts up.
+func sanityCheck(exe string) error {
+	cmd := exec.Command(exe, "--version")
+	cmd.Stdout = io.Discard
+	cmd.Stderr = io.Discard
+	return cmd.Run()
+}
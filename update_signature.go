@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// verifySignature checks signature as a detached OpenPGP signature of
+// data, against the pinned maintainerPublicKeyArmored. signature is the
+// raw contents of SHA256SUMS.asc, which GitHub releases ship as an
+// ASCII-armored detached signature, so it's unwrapped with armor.Decode
+// before being handed to openpgp's (binary-only) signature checker.
+func verifySignature(data, signature []byte) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(maintainerPublicKeyArmored))
+	if err != nil {
+		return fmt.Errorf("cannot parse pinned maintainer public key: %w", err)
+	}
+
+	block, err := armor.Decode(bytes.NewReader(signature))
+	if err != nil {
+		return fmt.Errorf("cannot decode armored signature: %w", err)
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data), block.Body); err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	return nil
+}
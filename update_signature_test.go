@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestVerifySignatureSuccess(t *testing.T) {
+	entity, armoredKey := generateTestKeyPair(t)
+
+	data := []byte("SHA256SUMS fixture contents\n")
+	var sigBuf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sigBuf, entity, bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := maintainerPublicKeyArmored
+	maintainerPublicKeyArmored = armoredKey
+	defer func() { maintainerPublicKeyArmored = previous }()
+
+	assert.NoError(t, verifySignature(data, sigBuf.Bytes()))
+}
+
+func TestVerifySignatureTamperedData(t *testing.T) {
+	entity, armoredKey := generateTestKeyPair(t)
+
+	data := []byte("SHA256SUMS fixture contents\n")
+	var sigBuf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sigBuf, entity, bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := maintainerPublicKeyArmored
+	maintainerPublicKeyArmored = armoredKey
+	defer func() { maintainerPublicKeyArmored = previous }()
+
+	assert.Error(t, verifySignature([]byte("tampered contents\n"), sigBuf.Bytes()))
+}
+
+func TestVerifySignatureWrongKey(t *testing.T) {
+	_, armoredKey := generateTestKeyPair(t)
+	otherEntity, _ := generateTestKeyPair(t)
+
+	data := []byte("SHA256SUMS fixture contents\n")
+	var sigBuf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sigBuf, otherEntity, bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := maintainerPublicKeyArmored
+	maintainerPublicKeyArmored = armoredKey
+	defer func() { maintainerPublicKeyArmored = previous }()
+
+	assert.Error(t, verifySignature(data, sigBuf.Bytes()))
+}
+
+// generateTestKeyPair creates a throwaway OpenPGP key pair and returns both
+// the entity (for signing) and its armored public key (for verifySignature).
+func generateTestKeyPair(t *testing.T) (*openpgp.Entity, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("Test Maintainer", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return entity, buf.String()
+}
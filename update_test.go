@@ -0,0 +1,227 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiblingAssetURL(t *testing.T) {
+	url := "https://github.com/creativeprojects/resticprofile/releases/download/v1.2.3/resticprofile_linux_amd64"
+	assert.Equal(t,
+		"https://github.com/creativeprojects/resticprofile/releases/download/v1.2.3/SHA256SUMS",
+		siblingAssetURL(url, "SHA256SUMS"))
+}
+
+func TestVerifyChecksumSuccess(t *testing.T) {
+	asset := []byte("fixture-binary-contents")
+	sum := sha256.Sum256(asset)
+	sums := []byte(fmt.Sprintf("%s  resticprofile_linux_amd64\n", hex.EncodeToString(sum[:])))
+
+	err := verifyChecksum(sums, "resticprofile_linux_amd64", asset)
+	assert.NoError(t, err)
+}
+
+func TestVerifyChecksumMismatch(t *testing.T) {
+	asset := []byte("fixture-binary-contents")
+	sums := []byte("0000000000000000000000000000000000000000000000000000000000000000  resticprofile_linux_amd64\n")
+
+	err := verifyChecksum(sums, "resticprofile_linux_amd64", asset)
+	assert.Error(t, err)
+}
+
+func TestVerifyChecksumMissingEntry(t *testing.T) {
+	asset := []byte("fixture-binary-contents")
+	sums := []byte("deadbeef  some_other_asset\n")
+
+	err := verifyChecksum(sums, "resticprofile_linux_amd64", asset)
+	assert.Error(t, err)
+}
+
+func TestStageAndReplaceInstallsNewBinary(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "resticprofile")
+
+	script := "#!/bin/sh\necho ok\nexit 0\n"
+	if err := os.WriteFile(exe, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	newScript := "#!/bin/sh\necho new\nexit 0\n"
+	err := stageAndReplace(exe, []byte(newScript))
+	assert.NoError(t, err)
+
+	installed, err := os.ReadFile(exe)
+	assert.NoError(t, err)
+	assert.Equal(t, newScript, string(installed))
+
+	backup, err := os.ReadFile(exe + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, script, string(backup))
+}
+
+func TestExtractAssetRawBinaryPassesThrough(t *testing.T) {
+	binary := []byte("raw-binary-contents")
+	extracted, err := extractAsset("resticprofile_windows_amd64.exe", binary, "resticprofile.exe")
+	assert.NoError(t, err)
+	assert.Equal(t, binary, extracted)
+}
+
+func TestExtractAssetFromTarGz(t *testing.T) {
+	content := []byte("#!/bin/sh\necho new\nexit 0\n")
+	archive := buildTarGz(t, "resticprofile", content)
+
+	extracted, err := extractAsset("resticprofile_linux_amd64.tar.gz", archive, "resticprofile")
+	assert.NoError(t, err)
+	assert.Equal(t, content, extracted)
+}
+
+func TestExtractAssetFromTarGzMissingBinary(t *testing.T) {
+	archive := buildTarGz(t, "README.md", []byte("not a binary"))
+
+	_, err := extractAsset("resticprofile_linux_amd64.tar.gz", archive, "resticprofile")
+	assert.Error(t, err)
+}
+
+func TestExtractAssetFromZip(t *testing.T) {
+	content := []byte("binary-contents")
+	archive := buildZip(t, "resticprofile.exe", content)
+
+	extracted, err := extractAsset("resticprofile_windows_amd64.zip", archive, "resticprofile.exe")
+	assert.NoError(t, err)
+	assert.Equal(t, content, extracted)
+}
+
+// TestDownloadVerifyExtractInstall exercises the full self-update pipeline
+// (download, checksum verification, archive extraction, staged install)
+// against a fake release server, without involving the real GitHub API.
+func TestDownloadVerifyExtractInstall(t *testing.T) {
+	content := []byte("#!/bin/sh\necho new\nexit 0\n")
+	archive := buildTarGz(t, "resticprofile", content)
+	archiveName := "resticprofile_linux_amd64.tar.gz"
+
+	sum := sha256.Sum256(archive)
+	sums := []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), archiveName))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+archiveName, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(sums)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	assetURL := server.URL + "/" + archiveName
+
+	asset, err := downloadFile(assetURL)
+	assert.NoError(t, err)
+
+	assert.NoError(t, verifyAsset(assetURL, asset))
+
+	binary, err := extractAsset(path.Base(assetURL), asset, "resticprofile")
+	assert.NoError(t, err)
+	assert.Equal(t, content, binary)
+
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "resticprofile")
+	assert.NoError(t, os.WriteFile(exe, []byte("#!/bin/sh\necho old\nexit 0\n"), 0755))
+
+	assert.NoError(t, stageAndReplace(exe, binary))
+
+	installed, err := os.ReadFile(exe)
+	assert.NoError(t, err)
+	assert.Equal(t, content, installed)
+}
+
+func TestDownloadVerifyFailsOnChecksumMismatch(t *testing.T) {
+	archive := buildTarGz(t, "resticprofile", []byte("content"))
+	archiveName := "resticprofile_linux_amd64.tar.gz"
+	sums := []byte("0000000000000000000000000000000000000000000000000000000000000000  " + archiveName + "\n")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+archiveName, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(sums)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	assetURL := server.URL + "/" + archiveName
+	asset, err := downloadFile(assetURL)
+	assert.NoError(t, err)
+
+	assert.Error(t, verifyAsset(assetURL, asset))
+}
+
+func buildTarGz(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStageAndReplaceRollsBackOnSanityCheckFailure(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "resticprofile")
+
+	script := "#!/bin/sh\necho ok\nexit 0\n"
+	if err := os.WriteFile(exe, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	brokenScript := "#!/bin/sh\nexit 1\n"
+	err := stageAndReplace(exe, []byte(brokenScript))
+	assert.Error(t, err)
+
+	installed, err := os.ReadFile(exe)
+	assert.NoError(t, err)
+	assert.Equal(t, script, string(installed))
+}